@@ -72,7 +72,9 @@ func unsafeCastToString(buf unsafe.Pointer, len C.size_t) string {
 //export cgoRedisRioRead
 func cgoRedisRioRead(rdb *C.rio, buf unsafe.Pointer, len C.size_t) C.size_t {
 	loader, buffer := unsafeCastToLoader(rdb), unsafeCastToSlice(buf, len)
-	return C.size_t(loader.onRead(buffer))
+	var n = loader.onRead(buffer)
+	loader.rio.count += int64(n)
+	return C.size_t(n)
 }
 
 //export cgoRedisRioWrite
@@ -96,11 +98,21 @@ func cgoRedisRioFlush(rdb *C.rio) C.int {
 //export cgoRedisRioUpdateChecksum
 func cgoRedisRioUpdateChecksum(rdb *C.rio, checksum C.uint64_t) {
 	loader := unsafeCastToLoader(rdb)
+	loader.rio.checksum = uint64(checksum)
 	loader.onUpdateChecksum(uint64(checksum))
 }
 
 type redisRio struct {
 	rdb C.rio
+
+	// count is the number of bytes consumed from the underlying reader so
+	// far, maintained by cgoRedisRioRead. It backs Loader.Progress and
+	// Loader.Rebind.
+	count int64
+
+	// checksum is the running RDB CRC64 as of the last update callback,
+	// maintained by cgoRedisRioUpdateChecksum.
+	checksum uint64
 }
 
 func (r *redisRio) init() {
@@ -116,56 +128,104 @@ func (r *redisRio) Read(b []byte) error {
 	return nil
 }
 
-func (r *redisRio) LoadLen() uint64 {
+func (r *redisRio) LoadLenE() (uint64, error) {
 	var len C.uint64_t
 	var ret = C.redisRioLoadLen(&r.rdb, &len)
 	if ret != 0 {
-		log.PanicErrorf(io.ErrUnexpectedEOF, "Read RDB LoadLen() failed")
+		return 0, errors.Trace(io.ErrUnexpectedEOF)
 	}
-	return uint64(len)
+	return uint64(len), nil
 }
 
-func (r *redisRio) LoadType() int {
+func (r *redisRio) LoadLen() uint64 {
+	var len, err = r.LoadLenE()
+	if err != nil {
+		log.PanicErrorf(err, "Read RDB LoadLen() failed")
+	}
+	return len
+}
+
+func (r *redisRio) LoadTypeE() (int, error) {
 	var typ C.int
 	var ret = C.redisRioLoadType(&r.rdb, &typ)
 	if ret != 0 {
-		log.PanicErrorf(io.ErrUnexpectedEOF, "Read RDB LoadType() failed.")
+		return 0, errors.Trace(io.ErrUnexpectedEOF)
 	}
-	return int(typ)
+	return int(typ), nil
 }
 
-func (r *redisRio) LoadTime() time.Duration {
+func (r *redisRio) LoadType() int {
+	var typ, err = r.LoadTypeE()
+	if err != nil {
+		log.PanicErrorf(err, "Read RDB LoadType() failed.")
+	}
+	return typ
+}
+
+func (r *redisRio) LoadTimeE() (time.Duration, error) {
 	var val C.time_t
 	var ret = C.redisRioLoadTime(&r.rdb, &val)
 	if ret != 0 {
-		log.PanicErrorf(io.ErrUnexpectedEOF, "Read RDB LoadTime() failed.")
+		return 0, errors.Trace(io.ErrUnexpectedEOF)
 	}
-	return time.Duration(val) * time.Second
+	return time.Duration(val) * time.Second, nil
 }
 
-func (r *redisRio) LoadTimeMillisecond() time.Duration {
+func (r *redisRio) LoadTime() time.Duration {
+	var val, err = r.LoadTimeE()
+	if err != nil {
+		log.PanicErrorf(err, "Read RDB LoadTime() failed.")
+	}
+	return val
+}
+
+func (r *redisRio) LoadTimeMillisecondE() (time.Duration, error) {
 	var val C.longlong
 	var ret = C.redisRioLoadTimeMillisecond(&r.rdb, &val)
 	if ret != 0 {
-		log.PanicErrorf(io.ErrUnexpectedEOF, "Read RDB LoadTimeMillisecond() failed.")
+		return 0, errors.Trace(io.ErrUnexpectedEOF)
 	}
-	return time.Duration(val) * time.Millisecond
+	return time.Duration(val) * time.Millisecond, nil
 }
 
-func (r *redisRio) LoadObject(typ int) *RedisObject {
+func (r *redisRio) LoadTimeMillisecond() time.Duration {
+	var val, err = r.LoadTimeMillisecondE()
+	if err != nil {
+		log.PanicErrorf(err, "Read RDB LoadTimeMillisecond() failed.")
+	}
+	return val
+}
+
+func (r *redisRio) LoadObjectE(typ int) (*RedisObject, error) {
 	var obj = C.redisRioLoadObject(&r.rdb, C.int(typ))
 	if obj == nil {
-		log.PanicErrorf(io.ErrUnexpectedEOF, "Read RDB LoadObject() failed.")
+		return nil, errors.Trace(io.ErrUnexpectedEOF)
 	}
-	return &RedisObject{obj}
+	return &RedisObject{obj}, nil
 }
 
-func (r *redisRio) LoadStringObject() *RedisStringObject {
+func (r *redisRio) LoadObject(typ int) *RedisObject {
+	var obj, err = r.LoadObjectE(typ)
+	if err != nil {
+		log.PanicErrorf(err, "Read RDB LoadObject() failed.")
+	}
+	return obj
+}
+
+func (r *redisRio) LoadStringObjectE() (*RedisStringObject, error) {
 	var obj = C.redisRioLoadStringObject(&r.rdb)
 	if obj == nil {
-		log.PanicErrorf(io.ErrUnexpectedEOF, "Read RDB LoadStringObject() failed.")
+		return nil, errors.Trace(io.ErrUnexpectedEOF)
+	}
+	return &RedisStringObject{&RedisObject{obj}}, nil
+}
+
+func (r *redisRio) LoadStringObject() *RedisStringObject {
+	var obj, err = r.LoadStringObjectE()
+	if err != nil {
+		log.PanicErrorf(err, "Read RDB LoadStringObject() failed.")
 	}
-	return &RedisStringObject{&RedisObject{obj}}
+	return obj
 }
 
 const (
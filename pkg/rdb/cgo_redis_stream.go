@@ -0,0 +1,173 @@
+package rdb
+
+// #cgo CFLAGS: -I.
+// #cgo CFLAGS: -I../../third_party/
+// #include "cgo_redis_stream.h"
+import "C"
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+func (o *RedisObject) IsStream() bool {
+	return o.Type() == OBJ_STREAM
+}
+
+func (o *RedisObject) AsStream() *RedisStreamObject {
+	return &RedisStreamObject{o}
+}
+
+type RedisStreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+func (id RedisStreamID) String() string {
+	return fmt.Sprintf("%d-%d", id.Ms, id.Seq)
+}
+
+type RedisStreamObject struct {
+	*RedisObject
+}
+
+func (o *RedisStreamObject) Len() int {
+	return int(C.redisStreamObjectLen(o.obj))
+}
+
+func (o *RedisStreamObject) LastID() RedisStreamID {
+	var ms, seq C.uint64_t
+	C.redisStreamObjectLastID(o.obj, &ms, &seq)
+	return RedisStreamID{uint64(ms), uint64(seq)}
+}
+
+func (o *RedisStreamObject) NewEntryIterator() *RedisStreamEntryIterator {
+	var iter = C.redisStreamObjectNewIterator(o.obj)
+	return &RedisStreamEntryIterator{iter}
+}
+
+type RedisStreamEntryIterator struct {
+	iter unsafe.Pointer
+}
+
+func (p *RedisStreamEntryIterator) Release() {
+	C.redisStreamIteratorRelease(p.iter)
+}
+
+func (p *RedisStreamEntryIterator) Next() (RedisStreamID, map[string]RedisUnsafeSds) {
+	var ms, seq C.uint64_t
+	var nfields C.size_t
+	var ret = C.redisStreamIteratorNext(p.iter, &ms, &seq, &nfields)
+	if ret == 0 {
+		return RedisStreamID{}, nil
+	}
+	var fields = make(map[string]RedisUnsafeSds, int(nfields))
+	for i := 0; i < int(nfields); i++ {
+		var kptr, vptr unsafe.Pointer
+		var klen, vlen C.size_t
+		C.redisStreamIteratorField(p.iter, C.size_t(i), &kptr, &klen, &vptr, &vlen)
+		fields[unsafeCastToString(kptr, klen)] = RedisUnsafeSds{vptr, int(vlen), 0}
+	}
+	return RedisStreamID{uint64(ms), uint64(seq)}, fields
+}
+
+type RedisStreamPELEntry struct {
+	ID            RedisStreamID
+	Consumer      string
+	DeliveryTime  time.Time
+	DeliveryCount uint64
+}
+
+type RedisStreamConsumer struct {
+	Name    string
+	Pending []RedisStreamID
+}
+
+type RedisStreamGroup struct {
+	Name      string
+	LastID    RedisStreamID
+	PEL       []RedisStreamPELEntry
+	Consumers []RedisStreamConsumer
+}
+
+func (o *RedisStreamObject) NewGroupIterator() *RedisStreamGroupIterator {
+	var iter = C.redisStreamObjectNewGroupIterator(o.obj)
+	return &RedisStreamGroupIterator{iter}
+}
+
+type RedisStreamGroupIterator struct {
+	iter unsafe.Pointer
+}
+
+func (p *RedisStreamGroupIterator) Release() {
+	C.redisStreamGroupIteratorRelease(p.iter)
+}
+
+func (p *RedisStreamGroupIterator) Next() *RedisStreamGroup {
+	var nameptr unsafe.Pointer
+	var namelen C.size_t
+	var lastMs, lastSeq C.uint64_t
+	var ret = C.redisStreamGroupIteratorNext(p.iter, &nameptr, &namelen, &lastMs, &lastSeq)
+	if ret == 0 {
+		return nil
+	}
+	var group = &RedisStreamGroup{
+		Name:   unsafeCastToString(nameptr, namelen),
+		LastID: RedisStreamID{uint64(lastMs), uint64(lastSeq)},
+	}
+	group.PEL = p.loadPEL()
+	group.Consumers = p.loadConsumers()
+	return group
+}
+
+func (p *RedisStreamGroupIterator) loadPEL() []RedisStreamPELEntry {
+	var pel []RedisStreamPELEntry
+	var iter = C.redisStreamGroupNewPELIterator(p.iter)
+	defer C.redisStreamPELIteratorRelease(iter)
+	for {
+		var ms, seq, deliveryCount C.uint64_t
+		var consumerPtr unsafe.Pointer
+		var consumerLen C.size_t
+		var deliveryTime C.longlong
+		var ret = C.redisStreamPELIteratorNext(iter, &ms, &seq, &consumerPtr, &consumerLen, &deliveryTime, &deliveryCount)
+		if ret == 0 {
+			return pel
+		}
+		var consumer string
+		if consumerPtr != nil {
+			consumer = unsafeCastToString(consumerPtr, consumerLen)
+		}
+		pel = append(pel, RedisStreamPELEntry{
+			ID:            RedisStreamID{uint64(ms), uint64(seq)},
+			Consumer:      consumer,
+			DeliveryTime:  time.Unix(0, int64(deliveryTime)*int64(time.Millisecond)),
+			DeliveryCount: uint64(deliveryCount),
+		})
+	}
+}
+
+func (p *RedisStreamGroupIterator) loadConsumers() []RedisStreamConsumer {
+	var consumers []RedisStreamConsumer
+	var citer = C.redisStreamGroupNewConsumerIterator(p.iter)
+	defer C.redisStreamConsumerIteratorRelease(citer)
+	for {
+		var nameptr unsafe.Pointer
+		var namelen C.size_t
+		var ret = C.redisStreamConsumerIteratorNext(citer, &nameptr, &namelen)
+		if ret == 0 {
+			return consumers
+		}
+		var consumer = RedisStreamConsumer{Name: unsafeCastToString(nameptr, namelen)}
+		var piter = C.redisStreamConsumerNewPendingIterator(citer)
+		for {
+			var ms, seq C.uint64_t
+			if C.redisStreamConsumerPendingIteratorNext(piter, &ms, &seq) == 0 {
+				break
+			}
+			consumer.Pending = append(consumer.Pending, RedisStreamID{uint64(ms), uint64(seq)})
+		}
+		C.redisStreamConsumerPendingIteratorRelease(piter)
+		consumers = append(consumers, consumer)
+	}
+}
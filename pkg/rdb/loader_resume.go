@@ -0,0 +1,86 @@
+package rdb
+
+import (
+	"io"
+	"time"
+)
+
+// LoadLenE, LoadTypeE, LoadTimeE, LoadTimeMillisecondE, LoadObjectE and
+// LoadStringObjectE mirror the exported Load* methods but return an error
+// on a short read instead of panicking, so callers can decode a truncated
+// RDB file, a partial replication buffer, or a blocking io.Reader without
+// aborting the process.
+
+func (l *Loader) LoadLenE() (uint64, error) {
+	return l.rio.LoadLenE()
+}
+
+func (l *Loader) LoadTypeE() (int, error) {
+	return l.rio.LoadTypeE()
+}
+
+func (l *Loader) LoadTimeE() (time.Duration, error) {
+	return l.rio.LoadTimeE()
+}
+
+func (l *Loader) LoadTimeMillisecondE() (time.Duration, error) {
+	return l.rio.LoadTimeMillisecondE()
+}
+
+func (l *Loader) LoadObjectE(typ int) (*RedisObject, error) {
+	return l.rio.LoadObjectE(typ)
+}
+
+func (l *Loader) LoadStringObjectE() (*RedisStringObject, error) {
+	return l.rio.LoadStringObjectE()
+}
+
+// Progress returns the number of bytes consumed from the underlying
+// reader so far, for callers that want to report or checkpoint decode
+// progress.
+func (l *Loader) Progress() int64 {
+	return l.rio.count
+}
+
+// Checksum returns the running RDB CRC64 as of the last checksum update,
+// for pairing with Progress when checkpointing decode state.
+func (l *Loader) Checksum() uint64 {
+	return l.rio.checksum
+}
+
+// Rebind reseeds the loader's internal byte counter and running CRC64 to
+// offset/checksum, the values a prior Loader reported via Progress and
+// Checksum just before a transient read error. It does not reposition
+// any reader itself, so callers that already have a Loader built some
+// other way and only need to reseed its bookkeeping around a reader they
+// repositioned themselves can call it directly; callers restarting a
+// decode from scratch should use Resume instead.
+//
+// l.rio.checksum is only a Go-side mirror of the embedded C rio's own
+// running CRC64 (kept in sync by cgoRedisRioUpdateChecksum); redisRioInit
+// always starts that C-side digest at zero, and nothing in this package
+// can seed it. So the value set here is overwritten by the digest of the
+// post-resume bytes alone on the very next read, and the EOF checksum a
+// resumed cgo Loader reports will not match the whole logical stream.
+// Checksum continuity across a resume would need a redisRioInit variant
+// that accepts a starting CRC64, which doesn't exist in this tree.
+func (l *Loader) Rebind(offset int64, checksum uint64) {
+	l.rio.count = offset
+	l.rio.checksum = checksum
+}
+
+// Resume builds a Loader that continues a previously interrupted decode
+// after a transient read error: r must already be positioned at offset
+// (a re-seeked file, an HTTP Range request, a fresh replication
+// connection that starts mid-stream), and offset is the value the prior
+// Loader reported via Progress just before the failing read, so Progress
+// on the returned Loader keeps counting from the right place. checksum
+// is accepted and forwarded to Rebind for callers that already track it,
+// but per Rebind's doc it does not make the resumed Loader's EOF
+// checksum match the whole stream; validate the checksum against the
+// pre-resume segment yourself, or skip the EOF check for resumed decodes.
+func Resume(r io.Reader, offset int64, checksum uint64) *Loader {
+	l := NewLoader(r)
+	l.Rebind(offset, checksum)
+	return l
+}
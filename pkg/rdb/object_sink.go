@@ -0,0 +1,167 @@
+package rdb
+
+import (
+	"time"
+
+	"github.com/CodisLabs/codis/pkg/utils/errors"
+)
+
+// Opcodes that LoadWithSink must step over rather than treat as an object
+// type. They aren't in the RDB_OPCODE_* block in cgo_redis.go because it
+// only exposes the opcodes the embedded redis build actually emits on
+// write; these are recognized on read regardless, since an RDB produced
+// by a newer/LFU-configured redis can still contain them.
+const (
+	rdbOpcodeFunction2 = 245
+	rdbOpcodeFunction  = 246
+	rdbOpcodeModuleAux = 247
+	rdbOpcodeIdle      = 248
+	rdbOpcodeFreq      = 249
+)
+
+// ObjectSink receives decoded RDB events one at a time, so callers can
+// spool or re-emit keys without holding every decoded RedisObject in
+// memory at once. See Loader.LoadWithSink.
+type ObjectSink interface {
+	OnAux(k, v string)
+	OnSelectDB(id int)
+	OnResizeDB(main, exp uint64)
+	OnKey(db int, key string, expireMs int64, obj *RedisObject) error
+	OnEOF(crc uint64) error
+}
+
+// LoadWithSink drives the Load*E pull API in a loop, dispatching each
+// decoded event to sink instead of returning every RedisObject to the
+// caller at once. It stops and returns the first error from either a
+// short read or a sink callback.
+func (l *Loader) LoadWithSink(sink ObjectSink) error {
+	var db int
+	for {
+		typ, err := l.LoadTypeE()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case RDB_OPCODE_EOF:
+			return sink.OnEOF(l.rio.checksum)
+
+		case RDB_OPCODE_AUX:
+			k, err := l.rio.LoadStringObjectE()
+			if err != nil {
+				return err
+			}
+			v, err := l.rio.LoadStringObjectE()
+			if err != nil {
+				return err
+			}
+			sink.OnAux(k.String(), v.String())
+
+		case RDB_OPCODE_SELECTDB:
+			n, err := l.LoadLenE()
+			if err != nil {
+				return err
+			}
+			db = int(n)
+			sink.OnSelectDB(db)
+
+		case RDB_OPCODE_RESIZEDB:
+			main, err := l.LoadLenE()
+			if err != nil {
+				return err
+			}
+			exp, err := l.LoadLenE()
+			if err != nil {
+				return err
+			}
+			sink.OnResizeDB(main, exp)
+
+		case RDB_OPCODE_EXPIRETIME:
+			d, err := l.LoadTimeE()
+			if err != nil {
+				return err
+			}
+			if err := l.loadKeyObjectWithSink(db, int64(d/time.Millisecond), sink); err != nil {
+				return err
+			}
+
+		case RDB_OPCODE_EXPIRETIME_MS:
+			d, err := l.LoadTimeMillisecondE()
+			if err != nil {
+				return err
+			}
+			if err := l.loadKeyObjectWithSink(db, int64(d/time.Millisecond), sink); err != nil {
+				return err
+			}
+
+		case rdbOpcodeIdle:
+			if _, err := l.LoadLenE(); err != nil {
+				return err
+			}
+
+		case rdbOpcodeFreq:
+			var b [1]byte
+			if err := l.rio.Read(b[:]); err != nil {
+				return err
+			}
+
+		case rdbOpcodeFunction, rdbOpcodeFunction2:
+			if _, err := l.rio.LoadStringObjectE(); err != nil {
+				return err
+			}
+
+		case rdbOpcodeModuleAux:
+			return errors.Errorf("rdb: RDB_OPCODE_MODULE_AUX is not supported by LoadWithSink")
+
+		default:
+			if err := l.loadObjectWithSink(db, 0, typ, sink); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// resolveValueType reads opcode/type bytes until it finds a real
+// object-type byte, skipping any IDLE/FREQ opcode redis may emit between
+// an EXPIRETIME(_MS) opcode and the value's type: rdbSaveKeyValuePair
+// writes expiretime, then idle/freq, then type, then key, then value.
+func (l *Loader) resolveValueType() (int, error) {
+	for {
+		typ, err := l.LoadTypeE()
+		if err != nil {
+			return 0, err
+		}
+		switch typ {
+		case rdbOpcodeIdle:
+			if _, err := l.LoadLenE(); err != nil {
+				return 0, err
+			}
+		case rdbOpcodeFreq:
+			var b [1]byte
+			if err := l.rio.Read(b[:]); err != nil {
+				return 0, err
+			}
+		default:
+			return typ, nil
+		}
+	}
+}
+
+func (l *Loader) loadKeyObjectWithSink(db int, expireMs int64, sink ObjectSink) error {
+	typ, err := l.resolveValueType()
+	if err != nil {
+		return err
+	}
+	return l.loadObjectWithSink(db, expireMs, typ, sink)
+}
+
+func (l *Loader) loadObjectWithSink(db int, expireMs int64, typ int, sink ObjectSink) error {
+	key, err := l.rio.LoadStringObjectE()
+	if err != nil {
+		return err
+	}
+	obj, err := l.LoadObjectE(typ)
+	if err != nil {
+		return err
+	}
+	return sink.OnKey(db, key.String(), expireMs, obj)
+}
@@ -0,0 +1,15 @@
+package purerdb
+
+import "github.com/CodisLabs/codis/pkg/utils/errors"
+
+// need reports an error if buf doesn't have n more bytes available
+// starting at offset p. Every container walker (ziplist, listpack,
+// intset, zipmap) calls this before slicing buf, so a truncated or
+// corrupt payload returns an error instead of panicking with an
+// index-out-of-range.
+func need(buf []byte, p, n int) error {
+	if p < 0 || n < 0 || p+n < p || p+n > len(buf) {
+		return errors.Errorf("pure rdb: truncated payload, need %d bytes at offset %d of %d", n, p, len(buf))
+	}
+	return nil
+}
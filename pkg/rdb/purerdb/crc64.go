@@ -0,0 +1,30 @@
+package purerdb
+
+// crc64Table implements the Jones CRC-64 variant (polynomial
+// 0xad93d23594c935a9, reflected), the checksum redis appends to the end
+// of every RDB file. This is a local copy of the table in package rdb's
+// crc64.go; purerdb must not import package rdb (see the package doc in
+// types.go).
+var crc64Table = func() [256]uint64 {
+	const poly = 0x95ac9329ac4bc9b5 // 0xad93d23594c935a9 bit-reversed
+	var table [256]uint64
+	for i := 0; i < 256; i++ {
+		crc := uint64(i)
+		for j := 0; j < 8; j++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ poly
+			} else {
+				crc >>= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+func crc64Update(crc uint64, buf []byte) uint64 {
+	for _, b := range buf {
+		crc = crc64Table[byte(crc)^b] ^ (crc >> 8)
+	}
+	return crc
+}
@@ -0,0 +1,228 @@
+package purerdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeZiplist(t *testing.T) {
+	// Two 6-bit-length string entries, "ab" then "cd".
+	entries := []byte{
+		0x00, 0x02, 'a', 'b', // prevlen=0, 6-bit len=2
+		0x04, 0x02, 'c', 'd', // prevlen=4, 6-bit len=2
+		0xff,
+	}
+	buf := ziplistHeader(entries, 2)
+	got, err := decodeZiplist(buf)
+	if err != nil {
+		t.Fatalf("decodeZiplist: %v", err)
+	}
+	if want := []string{"ab", "cd"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("decodeZiplist = %v, want %v", got, want)
+	}
+}
+
+func TestDecodeZiplistTruncated(t *testing.T) {
+	buf := ziplistHeader([]byte{0x00, 0x02, 'a'}, 1) // claims a 2-byte string, only has 1
+	if _, err := decodeZiplist(buf); err == nil {
+		t.Fatalf("decodeZiplist on truncated payload: want error, got nil")
+	}
+}
+
+func ziplistHeader(entries []byte, n int) []byte {
+	buf := make([]byte, 10+len(entries))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(buf)))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(10))
+	binary.LittleEndian.PutUint16(buf[8:10], uint16(n))
+	copy(buf[10:], entries)
+	return buf
+}
+
+func TestDecodeListpack(t *testing.T) {
+	// Two 6-bit-string-length entries, "ab" then "cd", each followed by a
+	// 1-byte backlen (the decoder only cares about its size, not value).
+	entries := []byte{
+		0x82, 'a', 'b', 0x03,
+		0x82, 'c', 'd', 0x03,
+		0xff,
+	}
+	buf := make([]byte, 6+len(entries))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(buf)))
+	binary.LittleEndian.PutUint16(buf[4:6], 2)
+	copy(buf[6:], entries)
+
+	got, err := decodeListpack(buf)
+	if err != nil {
+		t.Fatalf("decodeListpack: %v", err)
+	}
+	if want := []string{"ab", "cd"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("decodeListpack = %v, want %v", got, want)
+	}
+}
+
+func TestDecodeListpackTruncated(t *testing.T) {
+	buf := []byte{0x07, 0x00, 0x00, 0x00, 0x01, 0x00, 0x82, 'a'} // 6-bit len=2 but only 1 byte follows
+	if _, err := decodeListpack(buf); err == nil {
+		t.Fatalf("decodeListpack on truncated payload: want error, got nil")
+	}
+}
+
+func TestDecodeIntset(t *testing.T) {
+	buf := make([]byte, 8+4)
+	binary.LittleEndian.PutUint32(buf[0:4], 2) // encoding: int16
+	binary.LittleEndian.PutUint32(buf[4:8], 2) // length: 2 elements
+	binary.LittleEndian.PutUint16(buf[8:10], uint16(100))
+	neg5 := int16(-5)
+	binary.LittleEndian.PutUint16(buf[10:12], uint16(neg5))
+
+	got, err := decodeIntset(buf)
+	if err != nil {
+		t.Fatalf("decodeIntset: %v", err)
+	}
+	if want := []string{"100", "-5"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("decodeIntset = %v, want %v", got, want)
+	}
+}
+
+func TestDecodeIntsetTruncated(t *testing.T) {
+	buf := make([]byte, 8+1)
+	binary.LittleEndian.PutUint32(buf[0:4], 4) // encoding: int32, needs 4 bytes per element
+	binary.LittleEndian.PutUint32(buf[4:8], 1)
+	if _, err := decodeIntset(buf); err == nil {
+		t.Fatalf("decodeIntset on truncated payload: want error, got nil")
+	}
+}
+
+func TestDecodeZipmap(t *testing.T) {
+	buf := []byte{
+		0x01,           // zmlen hint, unused by the decoder
+		0x02, 'a', 'b', // key "ab"
+		0x02, 0x00, 'c', 'd', // value "cd", free=0
+		0xff,
+	}
+	got, err := decodeZipmap(buf)
+	if err != nil {
+		t.Fatalf("decodeZipmap: %v", err)
+	}
+	if want := []string{"ab", "cd"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("decodeZipmap = %v, want %v", got, want)
+	}
+}
+
+// TestZipmapDecodeLenNativeByteOrder pins the 5-byte length form to
+// little-endian, matching zipmapEncodeLength's raw memcpy of a native int
+// on every platform redis ships on.
+func TestZipmapDecodeLenNativeByteOrder(t *testing.T) {
+	buf := make([]byte, 5)
+	buf[0] = 254
+	binary.LittleEndian.PutUint32(buf[1:5], 300)
+
+	length, hdrSize, err := zipmapDecodeLen(buf, 0)
+	if err != nil {
+		t.Fatalf("zipmapDecodeLen: %v", err)
+	}
+	if length != 300 || hdrSize != 5 {
+		t.Fatalf("zipmapDecodeLen = (%d, %d), want (300, 5)", length, hdrSize)
+	}
+}
+
+func TestDecodeZipmapTruncated(t *testing.T) {
+	buf := []byte{0x01, 0x02, 'a'} // key claims length 2, only 1 byte follows
+	if _, err := decodeZipmap(buf); err == nil {
+		t.Fatalf("decodeZipmap on truncated payload: want error, got nil")
+	}
+}
+
+func TestLzfDecompressLiteral(t *testing.T) {
+	in := []byte{9, 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a'}
+	out, err := lzfDecompress(in, 10)
+	if err != nil {
+		t.Fatalf("lzfDecompress: %v", err)
+	}
+	if string(out) != "aaaaaaaaaa" {
+		t.Fatalf("lzfDecompress = %q, want %q", out, "aaaaaaaaaa")
+	}
+}
+
+func TestLzfDecompressBackReference(t *testing.T) {
+	// Literal "abc", then a back-reference copying those same 3 bytes.
+	in := []byte{2, 'a', 'b', 'c', 0x20, 2}
+	out, err := lzfDecompress(in, 6)
+	if err != nil {
+		t.Fatalf("lzfDecompress: %v", err)
+	}
+	if string(out) != "abcabc" {
+		t.Fatalf("lzfDecompress = %q, want %q", out, "abcabc")
+	}
+}
+
+func TestLzfDecompressTruncated(t *testing.T) {
+	in := []byte{2, 'a', 'b', 'c', 0x20} // back-reference offset byte missing
+	if _, err := lzfDecompress(in, 6); err == nil {
+		t.Fatalf("lzfDecompress on truncated payload: want error, got nil")
+	}
+}
+
+func TestLoadLen(t *testing.T) {
+	cases := []struct {
+		name    string
+		buf     []byte
+		want    uint64
+		encoded bool
+	}{
+		{"6bit", []byte{0x05}, 5, false},
+		{"14bit", []byte{0x41, 0x02}, 258, false},
+		{"32bit", append([]byte{0x80}, be32(70000)...), 70000, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l := NewLoader(bytes.NewReader(c.buf))
+			got, encoded, err := l.LoadLen()
+			if err != nil {
+				t.Fatalf("LoadLen: %v", err)
+			}
+			if got != c.want || encoded != c.encoded {
+				t.Fatalf("LoadLen = (%d, %v), want (%d, %v)", got, encoded, c.want, c.encoded)
+			}
+		})
+	}
+}
+
+func be32(v uint32) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, v)
+	return buf
+}
+
+// TestNextExpireThenFreq exercises the FREQ-between-EXPIRETIME_MS-and-type
+// ordering that an LFU-configured redis writes (rdbSaveKeyValuePair:
+// expiretime, then idle/freq, then type, then key, then value).
+func TestNextExpireThenFreq(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteByte(opExpireTimeMs)
+	expireMs := make([]byte, 8)
+	binary.LittleEndian.PutUint64(expireMs, 1700000000000)
+	buf.Write(expireMs)
+	buf.WriteByte(opFreq)
+	buf.WriteByte(5) // LFU counter
+	buf.WriteByte(typeString)
+	buf.WriteByte(3) // 6-bit length key
+	buf.WriteString("foo")
+	buf.WriteByte(3) // 6-bit length value
+	buf.WriteString("bar")
+
+	l := NewLoader(&buf)
+	ev, err := l.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if ev.Kind != EventKey || ev.Key != "foo" || ev.ExpireMs != 1700000000000 {
+		t.Fatalf("Next = %+v, want key=foo expireMs=1700000000000", ev)
+	}
+	s, ok := ev.Object.(*StringObject)
+	if !ok || s.Value != "bar" {
+		t.Fatalf("Next object = %+v, want StringObject{bar}", ev.Object)
+	}
+}
@@ -0,0 +1,46 @@
+package purerdb
+
+import (
+	"encoding/binary"
+
+	"github.com/CodisLabs/codis/pkg/utils/errors"
+)
+
+// decodeIntset walks an intset payload (the <encoding><length><elements>
+// layout used by RDB_TYPE_SET_INTSET) and returns its elements formatted
+// as decimal strings, in order. A truncated or corrupt payload returns an
+// error instead of panicking.
+func decodeIntset(buf []byte) ([]string, error) {
+	if len(buf) < 8 {
+		return nil, errors.Errorf("pure rdb: intset payload too short (%d bytes)", len(buf))
+	}
+	encoding := binary.LittleEndian.Uint32(buf[0:4])
+	length := binary.LittleEndian.Uint32(buf[4:8])
+	entries := make([]string, 0, length)
+	p := 8
+	for i := uint32(0); i < length; i++ {
+		switch encoding {
+		case 2:
+			if err := need(buf, p, 2); err != nil {
+				return nil, err
+			}
+			entries = append(entries, itoa(int64(int16(binary.LittleEndian.Uint16(buf[p:p+2])))))
+			p += 2
+		case 4:
+			if err := need(buf, p, 4); err != nil {
+				return nil, err
+			}
+			entries = append(entries, itoa(int64(int32(binary.LittleEndian.Uint32(buf[p:p+4])))))
+			p += 4
+		case 8:
+			if err := need(buf, p, 8); err != nil {
+				return nil, err
+			}
+			entries = append(entries, itoa(int64(binary.LittleEndian.Uint64(buf[p:p+8]))))
+			p += 8
+		default:
+			return entries, nil
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,127 @@
+package purerdb
+
+import (
+	"encoding/binary"
+
+	"github.com/CodisLabs/codis/pkg/utils/errors"
+)
+
+// decodeListpack walks a listpack payload (the <total-bytes><num-elements>
+// <entry>...<0xff> layout used by the RDB_TYPE_*_LISTPACK types and by
+// RDB_TYPE_LIST_QUICKLIST_2's packed nodes) and returns its entries as
+// strings, in order. A truncated or corrupt payload returns an error
+// instead of panicking.
+func decodeListpack(buf []byte) ([]string, error) {
+	if len(buf) < 7 {
+		return nil, errors.Errorf("pure rdb: listpack payload too short (%d bytes)", len(buf))
+	}
+	var entries []string
+	p := 6 // total-bytes(4) + num-elements(2)
+	for p < len(buf) && buf[p] != 0xff {
+		start := p
+		val, n, err := decodeListpackEntry(buf, p)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, val)
+		p += n
+		backlen := lpBacklenSize(p - start)
+		if err := need(buf, p, backlen); err != nil {
+			return nil, err
+		}
+		p += backlen
+	}
+	return entries, nil
+}
+
+// decodeListpackEntry decodes the single entry at buf[p:] and returns its
+// value along with the number of bytes the entry's encoding+data occupies
+// (not including the trailing backlen).
+func decodeListpackEntry(buf []byte, p int) (string, int, error) {
+	if err := need(buf, p, 1); err != nil {
+		return "", 0, err
+	}
+	b := buf[p]
+	switch {
+	case b&0x80 == 0: // 0xxxxxxx: 7-bit unsigned int
+		return itoa(int64(b & 0x7f)), 1, nil
+	case b&0xc0 == 0x80: // 10xxxxxx: 6-bit string length
+		n := int(b & 0x3f)
+		if err := need(buf, p+1, n); err != nil {
+			return "", 0, err
+		}
+		return string(buf[p+1 : p+1+n]), 1 + n, nil
+	case b&0xe0 == 0xc0: // 110xxxxx yyyyyyyy: 13-bit signed int
+		if err := need(buf, p, 2); err != nil {
+			return "", 0, err
+		}
+		v := int(b&0x1f)<<8 | int(buf[p+1])
+		if v&0x1000 != 0 {
+			v -= 8192
+		}
+		return itoa(int64(v)), 2, nil
+	case b&0xf0 == 0xe0: // 1110xxxx yyyyyyyy: 12-bit string length
+		if err := need(buf, p, 2); err != nil {
+			return "", 0, err
+		}
+		n := int(b&0x0f)<<8 | int(buf[p+1])
+		if err := need(buf, p+2, n); err != nil {
+			return "", 0, err
+		}
+		return string(buf[p+2 : p+2+n]), 2 + n, nil
+	case b == 0xf0: // 32-bit string length
+		if err := need(buf, p, 5); err != nil {
+			return "", 0, err
+		}
+		n := int(binary.LittleEndian.Uint32(buf[p+1 : p+5]))
+		if err := need(buf, p+5, n); err != nil {
+			return "", 0, err
+		}
+		return string(buf[p+5 : p+5+n]), 5 + n, nil
+	case b == 0xf1: // 16-bit signed int
+		if err := need(buf, p, 3); err != nil {
+			return "", 0, err
+		}
+		return itoa(int64(int16(binary.LittleEndian.Uint16(buf[p+1 : p+3])))), 3, nil
+	case b == 0xf2: // 24-bit signed int
+		if err := need(buf, p, 4); err != nil {
+			return "", 0, err
+		}
+		bs := buf[p+1 : p+4]
+		v := int32(bs[0]) | int32(bs[1])<<8 | int32(bs[2])<<16
+		if v&0x800000 != 0 {
+			v |= -1 << 24
+		}
+		return itoa(int64(v)), 4, nil
+	case b == 0xf3: // 32-bit signed int
+		if err := need(buf, p, 5); err != nil {
+			return "", 0, err
+		}
+		return itoa(int64(int32(binary.LittleEndian.Uint32(buf[p+1 : p+5])))), 5, nil
+	case b == 0xf4: // 64-bit signed int
+		if err := need(buf, p, 9); err != nil {
+			return "", 0, err
+		}
+		return itoa(int64(binary.LittleEndian.Uint64(buf[p+1 : p+9]))), 9, nil
+	default:
+		return "", 1, nil
+	}
+}
+
+// lpBacklenSize returns the number of bytes the backward-length field
+// takes for an entry of the given encoding+data length, per
+// lpEncodeBacklen in listpack.c.
+func lpBacklenSize(l int) int {
+	switch {
+	case l <= 127:
+		return 1
+	case l < 16384:
+		return 2
+	case l < 2097152:
+		return 3
+	case l < 268435456:
+		return 4
+	default:
+		return 5
+	}
+}
@@ -0,0 +1,465 @@
+package purerdb
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+
+	"github.com/CodisLabs/codis/pkg/utils/errors"
+)
+
+type EventKind int
+
+const (
+	EventAux EventKind = iota
+	EventSelectDB
+	EventResizeDB
+	EventKey
+	EventEOF
+)
+
+// Event is one decoded unit of an RDB stream, emitted in order by
+// Loader.Next.
+type Event struct {
+	Kind EventKind
+
+	AuxKey, AuxValue string
+
+	DBNum int
+
+	ResizeDBMain, ResizeDBExpires uint64
+
+	Key      string
+	ExpireMs int64
+	Object   Object
+
+	EOFChecksum uint64
+}
+
+// Next decodes and returns the next event in the stream. The trailing
+// RDB_OPCODE_EOF and its checksum are returned as an EventEOF event with
+// a nil error; a call after that returns the reader's error.
+func (l *Loader) Next() (*Event, error) {
+	op, err := l.readByte()
+	if err != nil {
+		return nil, err
+	}
+	switch int(op) {
+	case opEOF:
+		expect := l.crc
+		buf, err := l.readRaw(8)
+		if err != nil {
+			return nil, err
+		}
+		checksum := binary.LittleEndian.Uint64(buf[:8])
+		if checksum != 0 && checksum != expect {
+			return nil, errors.Errorf("pure rdb: checksum mismatch, expect 0x%x but got 0x%x", checksum, expect)
+		}
+		return &Event{Kind: EventEOF, EOFChecksum: checksum}, nil
+
+	case opAux:
+		k, err := l.LoadString()
+		if err != nil {
+			return nil, err
+		}
+		v, err := l.LoadString()
+		if err != nil {
+			return nil, err
+		}
+		return &Event{Kind: EventAux, AuxKey: k, AuxValue: v}, nil
+
+	case opSelectDB:
+		n, _, err := l.LoadLen()
+		if err != nil {
+			return nil, err
+		}
+		return &Event{Kind: EventSelectDB, DBNum: int(n)}, nil
+
+	case opResizeDB:
+		main, _, err := l.LoadLen()
+		if err != nil {
+			return nil, err
+		}
+		exp, _, err := l.LoadLen()
+		if err != nil {
+			return nil, err
+		}
+		return &Event{Kind: EventResizeDB, ResizeDBMain: main, ResizeDBExpires: exp}, nil
+
+	case opExpireTime:
+		buf, err := l.readFull(4)
+		if err != nil {
+			return nil, err
+		}
+		expireMs := int64(binary.LittleEndian.Uint32(buf)) * 1000
+		typ, err := l.resolveValueType()
+		if err != nil {
+			return nil, err
+		}
+		l.pendingType = typ
+		return l.loadKeyValue(expireMs)
+
+	case opExpireTimeMs:
+		buf, err := l.readFull(8)
+		if err != nil {
+			return nil, err
+		}
+		expireMs := int64(binary.LittleEndian.Uint64(buf))
+		typ, err := l.resolveValueType()
+		if err != nil {
+			return nil, err
+		}
+		l.pendingType = typ
+		return l.loadKeyValue(expireMs)
+
+	case opIdle:
+		if _, _, err := l.LoadLen(); err != nil {
+			return nil, err
+		}
+		return l.Next()
+
+	case opFreq:
+		if _, err := l.readByte(); err != nil {
+			return nil, err
+		}
+		return l.Next()
+
+	case opFunction, opFunction2:
+		if _, err := l.LoadString(); err != nil {
+			return nil, err
+		}
+		return l.Next()
+
+	case opModuleAux:
+		return nil, errors.Errorf("pure rdb: RDB_OPCODE_MODULE_AUX is not supported by the pure-Go decoder")
+
+	default:
+		l.pendingType = int(op)
+		return l.loadKeyValue(0)
+	}
+}
+
+// resolveValueType reads opcode bytes until it finds a real object-type
+// byte, skipping any IDLE/FREQ opcode redis may emit between an
+// EXPIRETIME(_MS) opcode and the value's type: rdbSaveKeyValuePair
+// writes expiretime, then idle/freq, then type, then key, then value.
+func (l *Loader) resolveValueType() (int, error) {
+	for {
+		b, err := l.readByte()
+		if err != nil {
+			return 0, err
+		}
+		switch int(b) {
+		case opIdle:
+			if _, _, err := l.LoadLen(); err != nil {
+				return 0, err
+			}
+		case opFreq:
+			if _, err := l.readByte(); err != nil {
+				return 0, err
+			}
+		default:
+			return int(b), nil
+		}
+	}
+}
+
+func (l *Loader) loadKeyValue(expireMs int64) (*Event, error) {
+	typ := l.pendingType
+	l.pendingType = -1
+	if typ < 0 {
+		b, err := l.readByte()
+		if err != nil {
+			return nil, err
+		}
+		typ = int(b)
+	}
+	key, err := l.LoadString()
+	if err != nil {
+		return nil, err
+	}
+	obj, err := l.loadObject(typ)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{Kind: EventKey, Key: key, ExpireMs: expireMs, Object: obj}, nil
+}
+
+func (l *Loader) loadObject(typ int) (Object, error) {
+	switch typ {
+	case typeString:
+		s, err := l.LoadString()
+		if err != nil {
+			return nil, err
+		}
+		return &StringObject{Value: s}, nil
+
+	case typeList:
+		n, _, err := l.LoadLen()
+		if err != nil {
+			return nil, err
+		}
+		list := &ListObject{}
+		for i := uint64(0); i < n; i++ {
+			v, err := l.LoadString()
+			if err != nil {
+				return nil, err
+			}
+			list.Values = append(list.Values, v)
+		}
+		return list, nil
+
+	case typeSet:
+		n, _, err := l.LoadLen()
+		if err != nil {
+			return nil, err
+		}
+		set := &SetObject{}
+		for i := uint64(0); i < n; i++ {
+			v, err := l.LoadString()
+			if err != nil {
+				return nil, err
+			}
+			set.Values = append(set.Values, v)
+		}
+		return set, nil
+
+	case typeHash:
+		n, _, err := l.LoadLen()
+		if err != nil {
+			return nil, err
+		}
+		hash := &HashObject{Values: make(map[string]string, n)}
+		for i := uint64(0); i < n; i++ {
+			k, err := l.LoadString()
+			if err != nil {
+				return nil, err
+			}
+			v, err := l.LoadString()
+			if err != nil {
+				return nil, err
+			}
+			hash.Values[k] = v
+		}
+		return hash, nil
+
+	case typeZset, typeZset2:
+		n, _, err := l.LoadLen()
+		if err != nil {
+			return nil, err
+		}
+		zset := &ZsetObject{Values: make(map[string]float64, n)}
+		for i := uint64(0); i < n; i++ {
+			k, err := l.LoadString()
+			if err != nil {
+				return nil, err
+			}
+			var score float64
+			if typ == typeZset2 {
+				buf, err := l.readFull(8)
+				if err != nil {
+					return nil, err
+				}
+				score = math.Float64frombits(binary.LittleEndian.Uint64(buf))
+			} else {
+				score, err = l.loadDoubleString()
+				if err != nil {
+					return nil, err
+				}
+			}
+			zset.Values[k] = score
+		}
+		return zset, nil
+
+	case typeListZiplist:
+		buf, err := l.LoadString()
+		if err != nil {
+			return nil, err
+		}
+		values, err := decodeZiplist([]byte(buf))
+		if err != nil {
+			return nil, err
+		}
+		return &ListObject{Values: values}, nil
+
+	case typeHashZiplist:
+		buf, err := l.LoadString()
+		if err != nil {
+			return nil, err
+		}
+		values, err := decodeZiplist([]byte(buf))
+		if err != nil {
+			return nil, err
+		}
+		return &HashObject{Values: pairsToMap(values)}, nil
+
+	case typeZsetZiplist:
+		buf, err := l.LoadString()
+		if err != nil {
+			return nil, err
+		}
+		values, err := decodeZiplist([]byte(buf))
+		if err != nil {
+			return nil, err
+		}
+		return &ZsetObject{Values: pairsToScores(values)}, nil
+
+	case typeHashZipmap:
+		buf, err := l.LoadString()
+		if err != nil {
+			return nil, err
+		}
+		values, err := decodeZipmap([]byte(buf))
+		if err != nil {
+			return nil, err
+		}
+		return &HashObject{Values: pairsToMap(values)}, nil
+
+	case typeSetIntset:
+		buf, err := l.LoadString()
+		if err != nil {
+			return nil, err
+		}
+		values, err := decodeIntset([]byte(buf))
+		if err != nil {
+			return nil, err
+		}
+		return &SetObject{Values: values}, nil
+
+	case typeListQuicklist:
+		n, _, err := l.LoadLen()
+		if err != nil {
+			return nil, err
+		}
+		list := &ListObject{}
+		for i := uint64(0); i < n; i++ {
+			node, err := l.LoadString()
+			if err != nil {
+				return nil, err
+			}
+			values, err := decodeZiplist([]byte(node))
+			if err != nil {
+				return nil, err
+			}
+			list.Values = append(list.Values, values...)
+		}
+		return list, nil
+
+	case typeListQuicklist2:
+		n, _, err := l.LoadLen()
+		if err != nil {
+			return nil, err
+		}
+		list := &ListObject{}
+		for i := uint64(0); i < n; i++ {
+			container, _, err := l.LoadLen()
+			if err != nil {
+				return nil, err
+			}
+			node, err := l.LoadString()
+			if err != nil {
+				return nil, err
+			}
+			if container == quicklistNodeContainerPlain {
+				list.Values = append(list.Values, node)
+			} else {
+				values, err := decodeListpack([]byte(node))
+				if err != nil {
+					return nil, err
+				}
+				list.Values = append(list.Values, values...)
+			}
+		}
+		return list, nil
+
+	case typeHashListpack:
+		buf, err := l.LoadString()
+		if err != nil {
+			return nil, err
+		}
+		values, err := decodeListpack([]byte(buf))
+		if err != nil {
+			return nil, err
+		}
+		return &HashObject{Values: pairsToMap(values)}, nil
+
+	case typeZsetListpack:
+		buf, err := l.LoadString()
+		if err != nil {
+			return nil, err
+		}
+		values, err := decodeListpack([]byte(buf))
+		if err != nil {
+			return nil, err
+		}
+		return &ZsetObject{Values: pairsToScores(values)}, nil
+
+	case typeSetListpack:
+		buf, err := l.LoadString()
+		if err != nil {
+			return nil, err
+		}
+		values, err := decodeListpack([]byte(buf))
+		if err != nil {
+			return nil, err
+		}
+		return &SetObject{Values: values}, nil
+
+	case typeStreamListpacks, typeStreamListpacks2, typeStreamListpacks3:
+		// Streams are intentionally out of scope here: their RDB layout
+		// is a rax of listpacks plus consumer-group/PEL bookkeeping, with
+		// no length-prefixed envelope to skip over opaquely. Decoding
+		// streams already has a dedicated, complete path in package rdb
+		// (RedisStreamObject, via cgo), so rather than guess at a partial
+		// Go re-implementation we fail loudly instead of silently
+		// mis-parsing the rest of the file.
+		return nil, errors.Errorf("pure rdb: stream values (type %d) are not supported by the pure-Go decoder; use rdb.Loader/RedisStreamObject instead", typ)
+
+	default:
+		return nil, errors.Errorf("pure rdb: unsupported object type %d", typ)
+	}
+}
+
+const quicklistNodeContainerPlain = 1
+
+func pairsToMap(entries []string) map[string]string {
+	m := make(map[string]string, len(entries)/2)
+	for i := 0; i+1 < len(entries); i += 2 {
+		m[entries[i]] = entries[i+1]
+	}
+	return m
+}
+
+func pairsToScores(entries []string) map[string]float64 {
+	m := make(map[string]float64, len(entries)/2)
+	for i := 0; i+1 < len(entries); i += 2 {
+		m[entries[i]] = parseFloatOrZero(entries[i+1])
+	}
+	return m
+}
+
+func (l *Loader) loadDoubleString() (float64, error) {
+	b, err := l.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch b {
+	case 255:
+		return math.Inf(-1), nil
+	case 254:
+		return math.Inf(1), nil
+	case 253:
+		return math.NaN(), nil
+	default:
+		buf, err := l.readFull(int(b))
+		if err != nil {
+			return 0, err
+		}
+		return parseFloatOrZero(string(buf)), nil
+	}
+}
+
+func parseFloatOrZero(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
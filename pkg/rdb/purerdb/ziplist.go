@@ -0,0 +1,114 @@
+package purerdb
+
+import (
+	"encoding/binary"
+
+	"github.com/CodisLabs/codis/pkg/utils/errors"
+)
+
+// decodeZiplist walks a ziplist payload (the <zlbytes><zltail><zllen>
+// <entry>...<0xff> layout used by the RDB_TYPE_*_ZIPLIST types, each entry
+// being <prevlen><encoding><data>) and returns its
+// entries as strings, in order. A truncated or corrupt payload returns an
+// error instead of panicking.
+func decodeZiplist(buf []byte) ([]string, error) {
+	if len(buf) < 11 {
+		return nil, errors.Errorf("pure rdb: ziplist payload too short (%d bytes)", len(buf))
+	}
+	var entries []string
+	p := 10 // zlbytes(4) + zltail(4) + zllen(2)
+	for p < len(buf) && buf[p] != 0xff {
+		if err := need(buf, p, 1); err != nil {
+			return nil, err
+		}
+		if buf[p] < 254 {
+			p++
+		} else {
+			if err := need(buf, p, 5); err != nil {
+				return nil, err
+			}
+			p += 5
+		}
+		if err := need(buf, p, 1); err != nil {
+			return nil, err
+		}
+		enc := buf[p]
+		switch {
+		case enc>>6 == 0: // 6-bit string length
+			n := int(enc & 0x3f)
+			p++
+			if err := need(buf, p, n); err != nil {
+				return nil, err
+			}
+			entries = append(entries, string(buf[p:p+n]))
+			p += n
+		case enc>>6 == 1: // 14-bit string length
+			if err := need(buf, p, 2); err != nil {
+				return nil, err
+			}
+			n := int(enc&0x3f)<<8 | int(buf[p+1])
+			p += 2
+			if err := need(buf, p, n); err != nil {
+				return nil, err
+			}
+			entries = append(entries, string(buf[p:p+n]))
+			p += n
+		case enc == 0x80: // 32-bit string length
+			if err := need(buf, p, 5); err != nil {
+				return nil, err
+			}
+			n := int(binary.BigEndian.Uint32(buf[p+1 : p+5]))
+			p += 5
+			if err := need(buf, p, n); err != nil {
+				return nil, err
+			}
+			entries = append(entries, string(buf[p:p+n]))
+			p += n
+		default: // integer encodings, enc>>4 == 0xc..0xf
+			v, n, err := decodeZiplistInt(buf[p:], enc)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, itoa(v))
+			p += n
+		}
+	}
+	return entries, nil
+}
+
+func decodeZiplistInt(buf []byte, enc byte) (int64, int, error) {
+	switch enc {
+	case 0xc0:
+		if err := need(buf, 0, 3); err != nil {
+			return 0, 0, err
+		}
+		return int64(int16(binary.LittleEndian.Uint16(buf[1:3]))), 3, nil
+	case 0xd0:
+		if err := need(buf, 0, 5); err != nil {
+			return 0, 0, err
+		}
+		return int64(int32(binary.LittleEndian.Uint32(buf[1:5]))), 5, nil
+	case 0xe0:
+		if err := need(buf, 0, 9); err != nil {
+			return 0, 0, err
+		}
+		return int64(binary.LittleEndian.Uint64(buf[1:9])), 9, nil
+	case 0xf0:
+		if err := need(buf, 0, 4); err != nil {
+			return 0, 0, err
+		}
+		b := buf[1:4]
+		v := int32(b[0]) | int32(b[1])<<8 | int32(b[2])<<16
+		if v&0x800000 != 0 {
+			v |= -1 << 24
+		}
+		return int64(v), 4, nil
+	case 0xfe:
+		if err := need(buf, 0, 2); err != nil {
+			return 0, 0, err
+		}
+		return int64(int8(buf[1])), 2, nil
+	default: // 4-bit immediate, enc in [0xf1, 0xfd]
+		return int64(enc&0x0f) - 1, 1, nil
+	}
+}
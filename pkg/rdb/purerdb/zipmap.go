@@ -0,0 +1,69 @@
+package purerdb
+
+import (
+	"encoding/binary"
+
+	"github.com/CodisLabs/codis/pkg/utils/errors"
+)
+
+// decodeZipmap walks a zipmap payload (the legacy pre-2.6 RDB_TYPE_HASH_
+// ZIPMAP encoding) and returns its key/value pairs, in order, flattened
+// the same way decodeZiplist/decodeListpack do. A truncated or corrupt
+// payload returns an error instead of panicking.
+func decodeZipmap(buf []byte) ([]string, error) {
+	if len(buf) < 1 {
+		return nil, errors.Errorf("pure rdb: zipmap payload too short (%d bytes)", len(buf))
+	}
+	var entries []string
+	p := 1 // zmlen byte: a length hint, 254 means "unknown, must scan"
+	for p < len(buf) && buf[p] != 0xff {
+		klen, khdr, err := zipmapDecodeLen(buf, p)
+		if err != nil {
+			return nil, err
+		}
+		p += khdr
+		if err := need(buf, p, klen); err != nil {
+			return nil, err
+		}
+		key := string(buf[p : p+klen])
+		p += klen
+
+		vlen, vhdr, err := zipmapDecodeLen(buf, p)
+		if err != nil {
+			return nil, err
+		}
+		p += vhdr
+		if err := need(buf, p, 1); err != nil {
+			return nil, err
+		}
+		free := int(buf[p])
+		p++ // free byte
+		if err := need(buf, p, vlen+free); err != nil {
+			return nil, err
+		}
+		val := string(buf[p : p+vlen])
+		p += vlen + free
+
+		entries = append(entries, key, val)
+	}
+	return entries, nil
+}
+
+// zipmapDecodeLen decodes a zipmap length field at buf[p:] and returns
+// the length plus the number of header bytes it occupied.
+func zipmapDecodeLen(buf []byte, p int) (length int, hdrSize int, err error) {
+	if err := need(buf, p, 1); err != nil {
+		return 0, 0, err
+	}
+	b := buf[p]
+	if b < 254 {
+		return int(b), 1, nil
+	}
+	if err := need(buf, p+1, 4); err != nil {
+		return 0, 0, err
+	}
+	// zipmapEncodeLength in redis's zipmap.c stores the 32-bit length with
+	// a raw memcpy of a native int, not htonl, so it's little-endian on
+	// every platform redis actually ships on (x86/x86-64/arm/arm64).
+	return int(binary.LittleEndian.Uint32(buf[p+1 : p+5])), 5, nil
+}